@@ -10,10 +10,18 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	ShellyCloud ShellyCloudConfig `yaml:"shelly_cloud"`
-	InfluxDB    InfluxDBConfig    `yaml:"influxdb"`
-	PollInterval int              `yaml:"poll_interval"`
-	Devices     []DeviceConfig    `yaml:"devices"`
+	ShellyCloud  ShellyCloudConfig `yaml:"shelly_cloud"`
+	InfluxDB     InfluxDBConfig    `yaml:"influxdb"`
+	MQTT         MQTTConfig        `yaml:"mqtt"`
+	RateLimit    RateLimitConfig   `yaml:"rate_limit"`
+	PollInterval int               `yaml:"poll_interval"`
+	// Mode selects how device status is ingested: "poll" (default) uses the
+	// HTTP poller, "mqtt" only subscribes to MQTT, "both" runs them together.
+	Mode    string         `yaml:"mode"`
+	Devices []DeviceConfig `yaml:"devices"`
+	Rules   []RuleConfig   `yaml:"rules"`
+	Sinks   SinksConfig    `yaml:"sinks"`
+	HTTP    HTTPConfig     `yaml:"http"`
 }
 
 type ShellyCloudConfig struct {
@@ -28,11 +36,66 @@ type InfluxDBConfig struct {
 	Bucket string `yaml:"bucket"`
 }
 
+type MQTTConfig struct {
+	BrokerURL   string `yaml:"broker_url"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	TLS         bool   `yaml:"tls"`
+	TopicPrefix string `yaml:"topic_prefix"`
+}
+
+// RateLimitConfig controls how fast and how concurrently devices are polled.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+	Parallelism       int     `yaml:"parallelism"`
+}
+
+// RuleConfig is one alerting rule, e.g.
+//
+//	- when: "power > 2000 and online"
+//	  for: 5m
+//	  then: webhook#overload
+type RuleConfig struct {
+	When string `yaml:"when"`
+	For  string `yaml:"for"`
+	Then string `yaml:"then"`
+}
+
+// SinksConfig names the destinations rules' `then:` fields can reference.
+// "log" is always available; webhook sinks are referenced as
+// "webhook#<name>", the MQTT sink as "mqtt" (only available in mqtt/both mode).
+type SinksConfig struct {
+	Webhooks  map[string]string `yaml:"webhooks"`
+	MQTTTopic string            `yaml:"mqtt_topic"`
+}
+
+// HTTPConfig controls the built-in health/metrics/on-demand query server.
+// It's disabled unless ListenAddr is set.
+type HTTPConfig struct {
+	ListenAddr  string `yaml:"listen_addr"`
+	BearerToken string `yaml:"bearer_token"`
+}
+
 type DeviceConfig struct {
 	Name    string `yaml:"name"`
 	ID      string `yaml:"id"`
 	Type    string `yaml:"type"`
 	Channel int    `yaml:"channel"`
+
+	// Transport selects how the device is reached: "cloud" (default) routes
+	// through Shelly Cloud via ID; "local" talks directly to Host on the LAN.
+	Transport  string `yaml:"transport"`
+	Host       string `yaml:"host"`
+	Generation int    `yaml:"generation"`
+	Username   string `yaml:"username"`
+	Password   string `yaml:"password"`
+}
+
+// IsLocal reports whether this device should be polled directly on the LAN
+// rather than through Shelly Cloud.
+func (d DeviceConfig) IsLocal() bool {
+	return d.Transport == "local"
 }
 
 // LoadConfig loads configuration from file and environment variables
@@ -44,6 +107,12 @@ func LoadConfig() (*Config, error) {
 
 	config := &Config{
 		PollInterval: 5, // Default
+		Mode:         "poll",
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond: 0.9, // ~1100ms between requests, matching the old fixed sleep
+			Burst:             1,
+			Parallelism:       4,
+		},
 	}
 
 	// Try to load from YAML file
@@ -0,0 +1,47 @@
+package httpapi
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds the Prometheus collectors scraped at /metrics.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	PollDuration *prometheus.HistogramVec
+	DeviceOnline *prometheus.GaugeVec
+	Power        *prometheus.GaugeVec
+	Energy       *prometheus.GaugeVec
+	HTTPErrors   *prometheus.CounterVec
+}
+
+// NewMetrics registers and returns the collectors used by the poller.
+func NewMetrics() *Metrics {
+	reg := prometheus.NewRegistry()
+
+	m := &Metrics{
+		Registry: reg,
+		PollDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "shelly_poll_duration_seconds",
+			Help:    "Time taken to fetch and parse a single device's status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"device"}),
+		DeviceOnline: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "shelly_device_online",
+			Help: "1 if the device responded to its last poll, 0 otherwise.",
+		}, []string{"device", "device_id"}),
+		Power: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "shelly_device_power_watts",
+			Help: "Last reported instantaneous power draw, in watts.",
+		}, []string{"device", "device_id"}),
+		Energy: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "shelly_device_energy_total",
+			Help: "Last reported cumulative energy counter.",
+		}, []string{"device", "device_id"}),
+		HTTPErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "shelly_http_errors_total",
+			Help: "HTTP errors encountered while polling devices, labeled by status code.",
+		}, []string{"device", "status"}),
+	}
+
+	reg.MustRegister(m.PollDuration, m.DeviceOnline, m.Power, m.Energy, m.HTTPErrors)
+	return m
+}
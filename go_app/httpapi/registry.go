@@ -0,0 +1,53 @@
+// Package httpapi exposes the logger's health, metrics, and on-demand
+// device status over HTTP, for ops tooling that doesn't want to wait on
+// InfluxDB queries.
+package httpapi
+
+import (
+	"sync"
+	"time"
+)
+
+// DeviceState is the last known health of a single device, as observed by
+// the poller.
+type DeviceState struct {
+	LastPoll time.Time `json:"last_poll"`
+	Online   bool      `json:"online"`
+	LastErr  string    `json:"last_error,omitempty"`
+}
+
+// Registry tracks per-device health so /healthz can answer without
+// round-tripping to InfluxDB.
+type Registry struct {
+	mu      sync.RWMutex
+	devices map[string]DeviceState
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{devices: make(map[string]DeviceState)}
+}
+
+// Record updates a device's last-seen state after a poll attempt.
+func (r *Registry) Record(deviceID string, online bool, err error) {
+	state := DeviceState{LastPoll: time.Now(), Online: online}
+	if err != nil {
+		state.LastErr = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.devices[deviceID] = state
+}
+
+// Snapshot returns a copy of the current device states, keyed by device ID.
+func (r *Registry) Snapshot() map[string]DeviceState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[string]DeviceState, len(r.devices))
+	for k, v := range r.devices {
+		out[k] = v
+	}
+	return out
+}
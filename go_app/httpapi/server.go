@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"shelly-cloud-logger/shelly"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RefreshFunc triggers an immediate status fetch and parse for a single
+// device, bypassing the regular poll schedule.
+type RefreshFunc func(ctx context.Context, deviceID string) (*shelly.DeviceStatus, error)
+
+// Server exposes /healthz, /metrics, and an on-demand device status
+// endpoint over HTTP.
+type Server struct {
+	registry    *Registry
+	metrics     *Metrics
+	bearerToken string
+	refresh     RefreshFunc
+}
+
+// NewServer builds a Server. bearerToken may be empty to disable auth.
+func NewServer(registry *Registry, metrics *Metrics, bearerToken string, refresh RefreshFunc) *Server {
+	return &Server{registry: registry, metrics: metrics, bearerToken: bearerToken, refresh: refresh}
+}
+
+// Handler returns the http.Handler to listen with.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.Handle("/metrics", promhttp.HandlerFor(s.metrics.Registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/api/v1/devices/", s.handleDeviceStatus)
+	return s.withAuth(mux)
+}
+
+// withAuth requires a matching Bearer token on every request, if one is
+// configured.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	if s.bearerToken == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get("Authorization")
+		want := "Bearer " + s.bearerToken
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	snapshot := s.registry.Snapshot()
+
+	status := "ok"
+	for _, state := range snapshot {
+		if !state.Online {
+			status = "degraded"
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  status,
+		"devices": snapshot,
+	})
+}
+
+// handleDeviceStatus serves /api/v1/devices/{id}/status, forcing an
+// immediate poll of the named device rather than waiting for the next
+// scheduled one.
+func (s *Server) handleDeviceStatus(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/devices/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "status" {
+		http.NotFound(w, r)
+		return
+	}
+	deviceID := parts[0]
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	status, err := s.refresh(ctx, deviceID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to refresh %s: %v", deviceID, err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
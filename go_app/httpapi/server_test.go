@@ -0,0 +1,61 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"shelly-cloud-logger/shelly"
+)
+
+func newTestServer(bearerToken string) *Server {
+	return NewServer(NewRegistry(), NewMetrics(), bearerToken, func(ctx context.Context, deviceID string) (*shelly.DeviceStatus, error) {
+		return &shelly.DeviceStatus{Online: true}, nil
+	})
+}
+
+func TestAuthBearerToken(t *testing.T) {
+	cases := []struct {
+		name       string
+		authHeader string
+		wantStatus int
+	}{
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer wrong-token", http.StatusUnauthorized},
+		{"wrong scheme", "Basic c2VjcmV0", http.StatusUnauthorized},
+		{"token as prefix of correct one", "Bearer secret-tokenextra", http.StatusUnauthorized},
+		{"correct token", "Bearer secret-token", http.StatusOK},
+	}
+
+	server := newTestServer("secret-token")
+	handler := server.Handler()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			if c.authHeader != "" {
+				req.Header.Set("Authorization", c.authHeader)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != c.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, c.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAuthDisabledWhenNoBearerTokenConfigured(t *testing.T) {
+	server := newTestServer("")
+	handler := server.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d when no bearer token is configured", rec.Code, http.StatusOK)
+	}
+}
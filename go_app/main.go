@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"shelly-cloud-logger/httpapi"
+	"shelly-cloud-logger/mqtt"
+	"shelly-cloud-logger/rules"
 	"shelly-cloud-logger/shelly"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -20,19 +28,25 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	if len(config.Devices) == 0 {
+	if config.Mode != "mqtt" && len(config.Devices) == 0 {
 		log.Fatal("No devices configured! Please add devices to config.yaml")
 	}
-	if config.ShellyCloud.AuthKey == "" {
-		log.Fatal("Shelly Cloud auth_key not configured!")
+	if config.Mode != "mqtt" {
+		if config.ShellyCloud.AuthKey == "" {
+			log.Fatal("Shelly Cloud auth_key not configured!")
+		}
+		if config.ShellyCloud.ServerURI == "" {
+			log.Fatal("Shelly Cloud server_uri not configured!")
+		}
 	}
-	if config.ShellyCloud.ServerURI == "" {
-		log.Fatal("Shelly Cloud server_uri not configured!")
+	if config.Mode != "poll" && config.MQTT.BrokerURL == "" {
+		log.Fatal("mqtt.broker_url not configured!")
 	}
 	if config.InfluxDB.Token == "" {
 		log.Fatal("InfluxDB token not configured!")
 	}
 
+	log.Printf("Mode: %s", config.Mode)
 	log.Printf("Monitoring %d devices", len(config.Devices))
 	log.Printf("Shelly Cloud: %s", config.ShellyCloud.ServerURI)
 	log.Printf("InfluxDB: %s, Bucket: %s", config.InfluxDB.URL, config.InfluxDB.Bucket)
@@ -52,17 +66,68 @@ func main() {
     }()
 
 	shellyClient := shelly.NewClient(config.ShellyCloud.ServerURI, config.ShellyCloud.AuthKey)
+	localClient := shelly.NewLocalClient()
+
+	var subscriber *mqtt.Subscriber
+	if config.Mode == "mqtt" || config.Mode == "both" {
+		subscriber, err = mqtt.NewSubscriber(mqtt.Options{
+			BrokerURL:   config.MQTT.BrokerURL,
+			Username:    config.MQTT.Username,
+			Password:    config.MQTT.Password,
+			TLS:         config.MQTT.TLS,
+			TopicPrefix: config.MQTT.TopicPrefix,
+		}, writeAPI)
+		if err != nil {
+			log.Fatalf("Failed to start MQTT subscriber: %v", err)
+		}
+		if err := subscriber.Start(); err != nil {
+			log.Fatalf("Failed to subscribe to MQTT topics: %v", err)
+		}
+		defer subscriber.Stop()
+	}
+
+	rulesEngine := buildRulesEngine(config, subscriber)
+
+	var registry *httpapi.Registry
+	var metrics *httpapi.Metrics
+	if config.HTTP.ListenAddr != "" {
+		registry = httpapi.NewRegistry()
+		metrics = httpapi.NewMetrics()
+
+		server := httpapi.NewServer(registry, metrics, config.HTTP.BearerToken, func(ctx context.Context, deviceID string) (*shelly.DeviceStatus, error) {
+			return refreshDevice(ctx, config, shellyClient, localClient, deviceID)
+		})
+		go func() {
+			log.Printf("HTTP server listening on %s", config.HTTP.ListenAddr)
+			if err := http.ListenAndServe(config.HTTP.ListenAddr, server.Handler()); err != nil {
+				log.Fatalf("HTTP server failed: %v", err)
+			}
+		}()
+	}
+
+	limiter := NewRateLimiter(config.RateLimit.RequestsPerSecond, config.RateLimit.Burst)
+	defer limiter.Close()
+	scheduler := NewScheduler(shellyClient, localClient, writeAPI, limiter, config.RateLimit.Parallelism, rulesEngine, registry, metrics)
 
 	// Function to poll devices
 	pollDevices := func() {
-		log.Printf("Polling %d devices via Shelly Cloud API...", len(config.Devices))
-		for _, device := range config.Devices {
-			processDevice(shellyClient, writeAPI, device)
-			time.Sleep(1100 * time.Millisecond) // Rate limit
-		}
+		log.Printf("Polling %d devices (parallelism %d)...", len(config.Devices), config.RateLimit.Parallelism)
+		scheduler.PollAll(context.Background(), config.Devices)
 		log.Println("Polling complete")
 	}
 
+	// Handle graceful shutdown
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	if config.Mode == "mqtt" {
+		// MQTT-only mode: nothing to poll, just wait for shutdown.
+		<-stop
+		log.Println("Shutting down...")
+		writeAPI.Flush()
+		return
+	}
+
 	// Initial poll
 	pollDevices()
 
@@ -70,10 +135,6 @@ func main() {
 	ticker := time.NewTicker(time.Duration(config.PollInterval) * time.Minute)
 	defer ticker.Stop()
 
-	// Handle graceful shutdown
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
-
 	for {
 		select {
 		case <-ticker.C:
@@ -86,27 +147,122 @@ func main() {
 	}
 }
 
-func processDevice(client *shelly.Client, writeAPI api.WriteAPI, device DeviceConfig) {
-	rawStatus, err := client.GetDeviceStatusV2(device.ID)
+// buildRulesEngine wires up the configured alerting rules and sinks. It
+// returns nil if no rules are configured, so callers can skip evaluation
+// entirely.
+func buildRulesEngine(config *Config, subscriber *mqtt.Subscriber) *rules.Engine {
+	if len(config.Rules) == 0 {
+		return nil
+	}
+
+	sinks := map[string]rules.Sink{"log": rules.LogSink{}}
+	for name, url := range config.Sinks.Webhooks {
+		sinks["webhook#"+name] = rules.NewWebhookSink(url)
+	}
+	if config.Sinks.MQTTTopic != "" {
+		if subscriber == nil {
+			log.Fatal("sinks.mqtt_topic is set but mode is not mqtt/both")
+		}
+		sinks["mqtt"] = rules.NewMQTTSink(subscriber.Publish, config.Sinks.MQTTTopic)
+	}
+
+	engine := rules.NewEngine(sinks)
+	for _, rc := range config.Rules {
+		forDuration, err := time.ParseDuration(rc.For)
+		if err != nil {
+			log.Fatalf("rule %q: invalid for: %v", rc.When, err)
+		}
+		if err := engine.AddRule(rc.When, forDuration, rc.Then); err != nil {
+			log.Fatalf("invalid rule: %v", err)
+		}
+	}
+	return engine
+}
+
+// refreshDevice fetches and parses a single device's status on demand, for
+// the httpapi on-demand query endpoint.
+func refreshDevice(ctx context.Context, config *Config, cloudClient *shelly.Client, localClient *shelly.LocalClient, deviceID string) (*shelly.DeviceStatus, error) {
+	for _, device := range config.Devices {
+		if device.ID != deviceID {
+			continue
+		}
+
+		var rawStatus map[string]interface{}
+		var err error
+		if device.IsLocal() {
+			rawStatus, err = localClient.GetDeviceStatus(device.Host, device.Generation, device.Username, device.Password)
+		} else {
+			rawStatus, err = cloudClient.GetDeviceStatusV2(ctx, device.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return shelly.ParseDeviceStatus(rawStatus, device.Channel)
+	}
+	return nil, fmt.Errorf("unknown device id %q", deviceID)
+}
+
+func processDevice(ctx context.Context, cloudClient cloudStatusFetcher, localClient localStatusFetcher, writeAPI api.WriteAPI, rulesEngine *rules.Engine, registry *httpapi.Registry, metrics *httpapi.Metrics, device DeviceConfig) error {
+	start := time.Now()
+	if metrics != nil {
+		defer func() {
+			metrics.PollDuration.WithLabelValues(device.Name).Observe(time.Since(start).Seconds())
+		}()
+	}
+
+	var rawStatus map[string]interface{}
+	var err error
+	cloudAccessible := !device.IsLocal()
+
+	if device.IsLocal() {
+		rawStatus, err = localClient.GetDeviceStatus(device.Host, device.Generation, device.Username, device.Password)
+	} else {
+		rawStatus, err = cloudClient.GetDeviceStatusV2(ctx, device.ID)
+	}
+
 	if err != nil {
 		log.Printf("Failed to get status for %s (%s): %v", device.Name, device.ID, err)
 
+		if registry != nil {
+			registry.Record(device.ID, false, err)
+		}
+		if metrics != nil {
+			metrics.DeviceOnline.WithLabelValues(device.Name, device.ID).Set(0)
+			var apiErr *shelly.APIError
+			if errors.As(err, &apiErr) {
+				metrics.HTTPErrors.WithLabelValues(device.Name, strconv.Itoa(apiErr.StatusCode)).Inc()
+			}
+		}
+
 		// Log offline status
 		p := influxdb2.NewPointWithMeasurement("shelly_status").
 			AddTag("device", device.Name).
 			AddTag("device_id", device.ID).
 			AddTag("type", device.Type).
 			AddField("online", false).
-			AddField("cloud_accessible", false).
+			AddField("cloud_accessible", cloudAccessible).
 			SetTime(time.Now())
 		writeAPI.WritePoint(p)
-		return
+		return err
 	}
 
 	status, err := shelly.ParseDeviceStatus(rawStatus, device.Channel)
 	if err != nil {
 		log.Printf("Failed to parse status for %s: %v", device.Name, err)
-		return
+		return err
+	}
+
+	if registry != nil {
+		registry.Record(device.ID, status.Online, nil)
+	}
+	if metrics != nil {
+		online := 0.0
+		if status.Online {
+			online = 1.0
+		}
+		metrics.DeviceOnline.WithLabelValues(device.Name, device.ID).Set(online)
+		metrics.Power.WithLabelValues(device.Name, device.ID).Set(status.Power)
+		metrics.Energy.WithLabelValues(device.Name, device.ID).Set(status.Energy)
 	}
 
 	// Create InfluxDB point
@@ -115,7 +271,7 @@ func processDevice(client *shelly.Client, writeAPI api.WriteAPI, device DeviceCo
 		AddTag("device_id", device.ID).
 		AddTag("type", device.Type).
 		AddField("online", status.Online).
-		AddField("cloud_accessible", true).
+		AddField("cloud_accessible", cloudAccessible).
 		AddField("output", status.Output).
 		AddField("output_int", map[bool]int{true: 1, false: 0}[status.Output]).
 		AddField("power", status.Power).
@@ -131,9 +287,27 @@ func processDevice(client *shelly.Client, writeAPI api.WriteAPI, device DeviceCo
 	if status.Temperature != nil {
 		p.AddField("temperature", *status.Temperature)
 	}
+	if status.Humidity != nil {
+		p.AddField("humidity", *status.Humidity)
+	}
+	if status.Battery != nil {
+		p.AddField("battery", *status.Battery)
+	}
+	if status.PowerFactor != nil {
+		p.AddField("power_factor", *status.PowerFactor)
+	}
+	if status.PhasePower != nil {
+		p.AddField("phase_power_a", status.PhasePower[0])
+		p.AddField("phase_power_b", status.PhasePower[1])
+		p.AddField("phase_power_c", status.PhasePower[2])
+	}
 
 	writeAPI.WritePoint(p)
 
+	if rulesEngine != nil {
+		rulesEngine.Evaluate(device.Name, status)
+	}
+
 	onlineStr := "offline"
 	if status.Online {
 		onlineStr = "online"
@@ -143,4 +317,5 @@ func processDevice(client *shelly.Client, writeAPI api.WriteAPI, device DeviceCo
 		outputStr = "ON"
 	}
 	log.Printf("✓ %s (%s): %s (%.1fW)", device.Name, onlineStr, outputStr, status.Power)
+	return nil
 }
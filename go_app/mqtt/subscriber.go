@@ -0,0 +1,185 @@
+// Package mqtt mirrors Shelly's native MQTT topics into InfluxDB's
+// shelly_status measurement, the one the HTTP poller writes, so users on
+// slow cloud plans can get real-time updates without hammering the REST API.
+// Points are tagged only with device_id (taken from the topic) and
+// source=mqtt; the poller's device and type tags are not set, and field
+// names are the topic remainder (e.g. relay/0/power) or component_field for
+// RPC events, not the poller's field names.
+package mqtt
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+)
+
+// Options configures how Subscriber connects to the broker.
+type Options struct {
+	BrokerURL   string
+	Username    string
+	Password    string
+	TLS         bool
+	TopicPrefix string
+}
+
+// Subscriber subscribes to Shelly status topics and writes each update to
+// InfluxDB as it arrives.
+type Subscriber struct {
+	client      mqtt.Client
+	writeAPI    api.WriteAPI
+	topicPrefix string
+}
+
+// NewSubscriber connects to the MQTT broker described by opts. Call Start
+// to begin subscribing once connected.
+func NewSubscriber(opts Options, writeAPI api.WriteAPI) (*Subscriber, error) {
+	topicPrefix := opts.TopicPrefix
+	if topicPrefix == "" {
+		topicPrefix = "shellies"
+	}
+
+	clientOpts := mqtt.NewClientOptions().
+		AddBroker(opts.BrokerURL).
+		SetClientID("shelly-ontime-logger").
+		SetAutoReconnect(true)
+
+	if opts.Username != "" {
+		clientOpts.SetUsername(opts.Username)
+		clientOpts.SetPassword(opts.Password)
+	}
+
+	if opts.TLS {
+		if !strings.HasPrefix(opts.BrokerURL, "ssl://") && !strings.HasPrefix(opts.BrokerURL, "tls://") {
+			return nil, fmt.Errorf("mqtt.tls is set but broker_url %q does not use an ssl:// or tls:// scheme", opts.BrokerURL)
+		}
+		clientOpts.SetTLSConfig(&tls.Config{})
+	}
+
+	s := &Subscriber{writeAPI: writeAPI, topicPrefix: topicPrefix}
+
+	client := mqtt.NewClient(clientOpts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", opts.BrokerURL, token.Error())
+	}
+	s.client = client
+
+	return s, nil
+}
+
+// Start subscribes to the Shelly status and Gen2 event topics under
+// topicPrefix, e.g. shellies/<id>/status/switch:0, shellies/<id>/relay/0/power.
+func (s *Subscriber) Start() error {
+	topics := map[string]byte{
+		s.topicPrefix + "/+/status/#":    0,
+		s.topicPrefix + "/+/relay/+/#":   0,
+		s.topicPrefix + "/+/temperature": 0,
+		s.topicPrefix + "/+/events/rpc":  0,
+	}
+	if token := s.client.SubscribeMultiple(topics, s.handleMessage); token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to subscribe under %s: %w", s.topicPrefix, token.Error())
+	}
+	log.Printf("MQTT: subscribed to status, relay, temperature and events/rpc topics under %s/", s.topicPrefix)
+	return nil
+}
+
+// Stop disconnects from the broker.
+func (s *Subscriber) Stop() {
+	s.client.Disconnect(250)
+}
+
+// Publish publishes payload to topic on the subscriber's broker connection.
+// It satisfies rules.PublishFunc, letting the alerting engine publish
+// directly without depending on an MQTT client library itself.
+func (s *Subscriber) Publish(topic string, payload []byte) error {
+	token := s.client.Publish(topic, 0, false, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// handleMessage decomposes a Shelly MQTT topic into a device ID and field,
+// then writes it to InfluxDB under the shared shelly_status measurement.
+func (s *Subscriber) handleMessage(_ mqtt.Client, msg mqtt.Message) {
+	device, field, ok := splitTopic(msg.Topic(), s.topicPrefix)
+	if !ok {
+		return
+	}
+
+	if field == "events/rpc" {
+		s.handleRPCEvent(device, msg.Payload())
+		return
+	}
+
+	payload := strings.TrimSpace(string(msg.Payload()))
+
+	p := influxdb2.NewPointWithMeasurement("shelly_status").
+		AddTag("device_id", device).
+		AddTag("source", "mqtt").
+		SetTime(time.Now())
+
+	if f, err := strconv.ParseFloat(payload, 64); err == nil {
+		p.AddField(field, f)
+	} else {
+		p.AddField(field, payload)
+	}
+
+	s.writeAPI.WritePoint(p)
+}
+
+// splitTopic turns "shellies/<id>/relay/0/power" into ("<id>", "relay/0/power").
+func splitTopic(topic, prefix string) (device, field string, ok bool) {
+	trimmed := strings.TrimPrefix(topic, prefix+"/")
+	if trimmed == topic {
+		return "", "", false
+	}
+	parts := strings.SplitN(trimmed, "/", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// rpcNotification is a Gen2 JSON-RPC status notification published on
+// shellies/<id>/events/rpc.
+type rpcNotification struct {
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+}
+
+func (s *Subscriber) handleRPCEvent(device string, payload []byte) {
+	var notif rpcNotification
+	if err := json.Unmarshal(payload, &notif); err != nil {
+		log.Printf("MQTT: failed to decode rpc event from %s: %v", device, err)
+		return
+	}
+
+	p := influxdb2.NewPointWithMeasurement("shelly_status").
+		AddTag("device_id", device).
+		AddTag("source", "mqtt").
+		SetTime(time.Now())
+
+	wrote := false
+	for component, valueRaw := range notif.Params {
+		fields, ok := valueRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for field, v := range fields {
+			if f, ok := v.(float64); ok {
+				p.AddField(fmt.Sprintf("%s_%s", component, field), f)
+				wrote = true
+			}
+		}
+	}
+
+	if wrote {
+		s.writeAPI.WritePoint(p)
+	}
+}
@@ -0,0 +1,192 @@
+package rules
+
+import (
+	"fmt"
+
+	"shelly-cloud-logger/shelly"
+)
+
+// Node is a single expression AST node. Value evaluates it against a
+// device's parsed status.
+type Node interface {
+	Value(status *shelly.DeviceStatus) (interface{}, error)
+}
+
+// Literal is a constant number or boolean.
+type Literal struct {
+	Val interface{}
+}
+
+func (l *Literal) Value(_ *shelly.DeviceStatus) (interface{}, error) {
+	return l.Val, nil
+}
+
+// Property looks up a field on DeviceStatus by name, e.g. "power", "online".
+type Property struct {
+	Name string
+}
+
+func (p *Property) Value(status *shelly.DeviceStatus) (interface{}, error) {
+	accessor, ok := propertyAccessors[p.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown property %q", p.Name)
+	}
+	return accessor(status)
+}
+
+// HasCall implements HAS(field), true iff the named optional pointer field
+// (Voltage, Current, Temperature, ...) is present on the status.
+type HasCall struct {
+	Name string
+}
+
+func (h *HasCall) Value(status *shelly.DeviceStatus) (interface{}, error) {
+	check, ok := hasAccessors[h.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown property %q in HAS()", h.Name)
+	}
+	return check(status), nil
+}
+
+// UnaryOp is a prefix operator: "not" or "-".
+type UnaryOp struct {
+	Op string
+	X  Node
+}
+
+func (u *UnaryOp) Value(status *shelly.DeviceStatus) (interface{}, error) {
+	v, err := u.X.Value(status)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Op {
+	case "not":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("not: expected bool operand, got %T", v)
+		}
+		return !b, nil
+	case "-":
+		f, ok := toFloat(v)
+		if !ok {
+			return nil, fmt.Errorf("unary -: expected numeric operand, got %T", v)
+		}
+		return -f, nil
+	default:
+		return nil, fmt.Errorf("unknown unary operator %q", u.Op)
+	}
+}
+
+// BinaryOp is an infix operator: arithmetic (+ - * /), comparison
+// (> < >= <= == !=), or logical (and/or).
+type BinaryOp struct {
+	Op   string
+	L, R Node
+}
+
+func (b *BinaryOp) Value(status *shelly.DeviceStatus) (interface{}, error) {
+	lv, err := b.L.Value(status)
+	if err != nil {
+		return nil, err
+	}
+
+	// and/or short-circuit, so the right side is only evaluated if needed.
+	if b.Op == "and" || b.Op == "or" {
+		lb, ok := lv.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected bool operand, got %T", b.Op, lv)
+		}
+		if b.Op == "and" && !lb {
+			return false, nil
+		}
+		if b.Op == "or" && lb {
+			return true, nil
+		}
+		rv, err := b.R.Value(status)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := rv.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected bool operand, got %T", b.Op, rv)
+		}
+		return rb, nil
+	}
+
+	rv, err := b.R.Value(status)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.Op {
+	case "+", "-", "*", "/":
+		lf, lok := toFloat(lv)
+		rf, rok := toFloat(rv)
+		if !lok || !rok {
+			return nil, fmt.Errorf("%s: operands must be numbers", b.Op)
+		}
+		switch b.Op {
+		case "+":
+			return lf + rf, nil
+		case "-":
+			return lf - rf, nil
+		case "*":
+			return lf * rf, nil
+		case "/":
+			if rf == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return lf / rf, nil
+		}
+	case ">", "<", ">=", "<=":
+		lf, lok := toFloat(lv)
+		rf, rok := toFloat(rv)
+		if !lok || !rok {
+			return nil, fmt.Errorf("%s: operands must be numbers", b.Op)
+		}
+		switch b.Op {
+		case ">":
+			return lf > rf, nil
+		case "<":
+			return lf < rf, nil
+		case ">=":
+			return lf >= rf, nil
+		case "<=":
+			return lf <= rf, nil
+		}
+	case "==", "!=":
+		eq := valuesEqual(lv, rv)
+		if b.Op == "==" {
+			return eq, nil
+		}
+		return !eq, nil
+	}
+
+	return nil, fmt.Errorf("unknown binary operator %q", b.Op)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if af, aok := toFloat(a); aok {
+		if bf, bok := toFloat(b); bok {
+			return af == bf
+		}
+	}
+	if ab, aok := a.(bool); aok {
+		if bb, bok := b.(bool); bok {
+			return ab == bb
+		}
+	}
+	return false
+}
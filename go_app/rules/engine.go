@@ -0,0 +1,56 @@
+package rules
+
+import (
+	"log"
+	"time"
+
+	"shelly-cloud-logger/shelly"
+)
+
+// Engine holds the configured rules and sinks, and evaluates every rule
+// against each device's status as it's polled.
+type Engine struct {
+	rules []*Rule
+	sinks map[string]Sink
+}
+
+// NewEngine creates an Engine backed by the given named sinks (looked up by
+// each rule's Then field, e.g. "webhook#overload").
+func NewEngine(sinks map[string]Sink) *Engine {
+	return &Engine{sinks: sinks}
+}
+
+// AddRule compiles and registers a rule. when is also used as the rule's
+// name, since rule configs don't carry a separate name.
+func (e *Engine) AddRule(when string, forDuration time.Duration, then string) error {
+	rule, err := NewRule(when, when, then, forDuration)
+	if err != nil {
+		return err
+	}
+	e.rules = append(e.rules, rule)
+	return nil
+}
+
+// Evaluate runs every rule against a device's latest status, firing the
+// rule's sink once its `for:` debounce window has elapsed.
+func (e *Engine) Evaluate(deviceName string, status *shelly.DeviceStatus) {
+	for _, rule := range e.rules {
+		fire, err := rule.Evaluate(deviceName, status)
+		if err != nil {
+			log.Printf("rules: %v", err)
+			continue
+		}
+		if !fire {
+			continue
+		}
+
+		sink, ok := e.sinks[rule.Then]
+		if !ok {
+			log.Printf("rules: rule %q: no sink registered for %q", rule.Name, rule.Then)
+			continue
+		}
+		if err := sink.Fire(rule.Name, deviceName, status); err != nil {
+			log.Printf("rules: rule %q: sink %q failed: %v", rule.Name, rule.Then, err)
+		}
+	}
+}
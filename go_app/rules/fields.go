@@ -0,0 +1,89 @@
+package rules
+
+import (
+	"fmt"
+
+	"shelly-cloud-logger/shelly"
+)
+
+// propertyAccessors is the fixed field map rule expressions are validated
+// and evaluated against. Adding a new DeviceStatus field supported by rules
+// means adding an entry here.
+var propertyAccessors = map[string]func(*shelly.DeviceStatus) (interface{}, error){
+	"online": func(s *shelly.DeviceStatus) (interface{}, error) { return s.Online, nil },
+	"output": func(s *shelly.DeviceStatus) (interface{}, error) { return s.Output, nil },
+	"power":  func(s *shelly.DeviceStatus) (interface{}, error) { return s.Power, nil },
+	"energy": func(s *shelly.DeviceStatus) (interface{}, error) { return s.Energy, nil },
+	"voltage": func(s *shelly.DeviceStatus) (interface{}, error) {
+		if s.Voltage == nil {
+			return nil, fmt.Errorf("voltage is not present on this status, guard with HAS(voltage)")
+		}
+		return *s.Voltage, nil
+	},
+	"current": func(s *shelly.DeviceStatus) (interface{}, error) {
+		if s.Current == nil {
+			return nil, fmt.Errorf("current is not present on this status, guard with HAS(current)")
+		}
+		return *s.Current, nil
+	},
+	"temperature": func(s *shelly.DeviceStatus) (interface{}, error) {
+		if s.Temperature == nil {
+			return nil, fmt.Errorf("temperature is not present on this status, guard with HAS(temperature)")
+		}
+		return *s.Temperature, nil
+	},
+	"humidity": func(s *shelly.DeviceStatus) (interface{}, error) {
+		if s.Humidity == nil {
+			return nil, fmt.Errorf("humidity is not present on this status, guard with HAS(humidity)")
+		}
+		return *s.Humidity, nil
+	},
+	"battery": func(s *shelly.DeviceStatus) (interface{}, error) {
+		if s.Battery == nil {
+			return nil, fmt.Errorf("battery is not present on this status, guard with HAS(battery)")
+		}
+		return *s.Battery, nil
+	},
+	"power_factor": func(s *shelly.DeviceStatus) (interface{}, error) {
+		if s.PowerFactor == nil {
+			return nil, fmt.Errorf("power_factor is not present on this status, guard with HAS(power_factor)")
+		}
+		return *s.PowerFactor, nil
+	},
+	"phase_power_a": func(s *shelly.DeviceStatus) (interface{}, error) {
+		if s.PhasePower == nil {
+			return nil, fmt.Errorf("phase_power_a is not present on this status, guard with HAS(phase_power_a)")
+		}
+		return s.PhasePower[0], nil
+	},
+	"phase_power_b": func(s *shelly.DeviceStatus) (interface{}, error) {
+		if s.PhasePower == nil {
+			return nil, fmt.Errorf("phase_power_b is not present on this status, guard with HAS(phase_power_b)")
+		}
+		return s.PhasePower[1], nil
+	},
+	"phase_power_c": func(s *shelly.DeviceStatus) (interface{}, error) {
+		if s.PhasePower == nil {
+			return nil, fmt.Errorf("phase_power_c is not present on this status, guard with HAS(phase_power_c)")
+		}
+		return s.PhasePower[2], nil
+	},
+}
+
+// hasAccessors backs HAS(field): true iff the named optional pointer field
+// is non-nil. Non-optional fields are always present.
+var hasAccessors = map[string]func(*shelly.DeviceStatus) bool{
+	"online":        func(s *shelly.DeviceStatus) bool { return true },
+	"output":        func(s *shelly.DeviceStatus) bool { return true },
+	"power":         func(s *shelly.DeviceStatus) bool { return true },
+	"energy":        func(s *shelly.DeviceStatus) bool { return true },
+	"voltage":       func(s *shelly.DeviceStatus) bool { return s.Voltage != nil },
+	"current":       func(s *shelly.DeviceStatus) bool { return s.Current != nil },
+	"temperature":   func(s *shelly.DeviceStatus) bool { return s.Temperature != nil },
+	"humidity":      func(s *shelly.DeviceStatus) bool { return s.Humidity != nil },
+	"battery":       func(s *shelly.DeviceStatus) bool { return s.Battery != nil },
+	"power_factor":  func(s *shelly.DeviceStatus) bool { return s.PowerFactor != nil },
+	"phase_power_a": func(s *shelly.DeviceStatus) bool { return s.PhasePower != nil },
+	"phase_power_b": func(s *shelly.DeviceStatus) bool { return s.PhasePower != nil },
+	"phase_power_c": func(s *shelly.DeviceStatus) bool { return s.PhasePower != nil },
+}
@@ -0,0 +1,324 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parse compiles a `when:` expression like `power > 2000 and online` into
+// an AST, validating every identifier against the fixed field map up
+// front so bad rules fail at config load time rather than mid-poll.
+func Parse(expr string) (Node, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected token %q", p.peek().text)
+	}
+
+	if err := validate(node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// validate walks the AST checking every Property/HAS identifier against
+// the fixed field map.
+func validate(node Node) error {
+	switch n := node.(type) {
+	case *Literal:
+		return nil
+	case *Property:
+		if _, ok := propertyAccessors[n.Name]; !ok {
+			return fmt.Errorf("unknown identifier %q", n.Name)
+		}
+		return nil
+	case *HasCall:
+		if _, ok := hasAccessors[n.Name]; !ok {
+			return fmt.Errorf("unknown identifier %q in HAS()", n.Name)
+		}
+		return nil
+	case *UnaryOp:
+		return validate(n.X)
+	case *BinaryOp:
+		if err := validate(n.L); err != nil {
+			return err
+		}
+		return validate(n.R)
+	default:
+		return fmt.Errorf("unhandled node type %T", node)
+	}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")"})
+			i++
+		case strings.ContainsRune(">=<!", c):
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, token{tokOp, string(c) + "="})
+				i += 2
+			} else if c != '!' {
+				tokens = append(tokens, token{tokOp, string(c)})
+				i++
+			} else {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		case strings.ContainsRune("+-*/", c):
+			tokens = append(tokens, token{tokOp, string(c)})
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i])})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == ':') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+
+	tokens = append(tokens, token{tokEOF, ""})
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryOp{Op: "or", L: left, R: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for isKeyword(p.peek(), "and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryOp{Op: "and", L: left, R: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if isKeyword(p.peek(), "not") {
+		p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryOp{Op: "not", X: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokOp && isComparisonOp(t.text) {
+		p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryOp{Op: t.text, L: left, R: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (Node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind == tokOp && (t.text == "+" || t.text == "-") {
+			p.next()
+			right, err := p.parseMultiplicative()
+			if err != nil {
+				return nil, err
+			}
+			left = &BinaryOp{Op: t.text, L: left, R: right}
+			continue
+		}
+		return left, nil
+	}
+}
+
+func (p *parser) parseMultiplicative() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind == tokOp && (t.text == "*" || t.text == "/") {
+			p.next()
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			left = &BinaryOp{Op: t.text, L: left, R: right}
+			continue
+		}
+		return left, nil
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if t := p.peek(); t.kind == tokOp && t.text == "-" {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryOp{Op: "-", X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokNumber:
+		p.next()
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.text)
+		}
+		return &Literal{Val: f}, nil
+
+	case t.kind == tokLParen:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, fmt.Errorf("expected closing paren")
+		}
+		p.next()
+		return node, nil
+
+	case t.kind == tokIdent && strings.EqualFold(t.text, "true"):
+		p.next()
+		return &Literal{Val: true}, nil
+
+	case t.kind == tokIdent && strings.EqualFold(t.text, "false"):
+		p.next()
+		return &Literal{Val: false}, nil
+
+	case t.kind == tokIdent && strings.EqualFold(t.text, "has"):
+		p.next()
+		if p.peek().kind != tokLParen {
+			return nil, fmt.Errorf("expected ( after HAS")
+		}
+		p.next()
+		if p.peek().kind != tokIdent {
+			return nil, fmt.Errorf("expected property name inside HAS()")
+		}
+		name := strings.ToLower(p.next().text)
+		if p.peek().kind != tokRParen {
+			return nil, fmt.Errorf("expected ) to close HAS()")
+		}
+		p.next()
+		return &HasCall{Name: name}, nil
+
+	case t.kind == tokIdent:
+		p.next()
+		return &Property{Name: strings.ToLower(t.text)}, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected token %q", t.text)
+	}
+}
+
+func isKeyword(t token, word string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, word)
+}
+
+func isComparisonOp(op string) bool {
+	switch op {
+	case ">", "<", ">=", "<=", "==", "!=":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,55 @@
+package rules
+
+import (
+	"testing"
+
+	"shelly-cloud-logger/shelly"
+)
+
+func floatPtr(f float64) *float64 { return &f }
+
+func TestParseAndEvaluate(t *testing.T) {
+	status := &shelly.DeviceStatus{
+		Online:  true,
+		Power:   2500,
+		Voltage: floatPtr(230.5),
+	}
+
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{"power > 2000 and online", true},
+		{"power > 2000 and not online", false},
+		{"power < 2000 or online", true},
+		{"HAS(voltage) and voltage > 200", true},
+		{"HAS(current)", false},
+		{"(power / 2) > 1000", true},
+	}
+
+	for _, c := range cases {
+		node, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.expr, err)
+		}
+		got, err := node.Value(status)
+		if err != nil {
+			t.Fatalf("Value(%q): %v", c.expr, err)
+		}
+		if got != c.want {
+			t.Errorf("%q: got %v, want %v", c.expr, got, c.want)
+		}
+	}
+}
+
+func TestParseUnknownIdentifier(t *testing.T) {
+	if _, err := Parse("bogus_field > 1"); err == nil {
+		t.Fatal("expected an error for an unknown identifier")
+	}
+}
+
+func TestParseUnknownHasIdentifier(t *testing.T) {
+	if _, err := Parse("HAS(bogus_field)"); err == nil {
+		t.Fatal("expected an error for an unknown HAS() identifier")
+	}
+}
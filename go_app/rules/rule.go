@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"shelly-cloud-logger/shelly"
+)
+
+// Rule evaluates a compiled `when:` expression against a device's status on
+// every poll, debouncing via `for:` so a single noisy reading doesn't fire
+// an alert.
+//
+// A single Rule is shared and evaluated concurrently across every device, so
+// its debounce state is tracked per device rather than as one field on the
+// Rule itself; state is guarded by mu.
+type Rule struct {
+	Name string
+	When string
+	For  time.Duration
+	Then string
+
+	ast Node
+
+	mu    sync.Mutex
+	state map[string]*violationState // keyed by device name
+}
+
+// violationState tracks one device's progress through a single violation
+// episode: when it started, and whether it has already fired, so a
+// sustained violation fires once rather than on every poll until it clears.
+type violationState struct {
+	since time.Time
+	fired bool
+}
+
+// NewRule compiles a when expression into a Rule. name identifies the rule
+// in errors, logs and sink payloads; it's conventionally the when expression
+// itself since rule configs don't carry a separate name field.
+func NewRule(name, when, then string, forDuration time.Duration) (*Rule, error) {
+	ast, err := Parse(when)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %w", name, err)
+	}
+	return &Rule{Name: name, When: when, For: forDuration, Then: then, ast: ast, state: make(map[string]*violationState)}, nil
+}
+
+// Evaluate runs the rule's expression against status and reports whether it
+// should fire this poll. A violation fires once, on the poll where it's
+// been continuously true for at least r.For; it re-arms and can fire again
+// only after the expression goes false and becomes true again. deviceName
+// keys the per-device state, since a single Rule is evaluated against every
+// device.
+func (r *Rule) Evaluate(deviceName string, status *shelly.DeviceStatus) (bool, error) {
+	val, err := r.ast.Value(status)
+	if err != nil {
+		return false, fmt.Errorf("rule %q: %w", r.Name, err)
+	}
+	truthy, ok := val.(bool)
+	if !ok {
+		return false, fmt.Errorf("rule %q: when expression must evaluate to a bool, got %T", r.Name, val)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !truthy {
+		delete(r.state, deviceName)
+		return false, nil
+	}
+
+	s, ok := r.state[deviceName]
+	if !ok {
+		s = &violationState{since: time.Now()}
+		r.state[deviceName] = s
+	}
+
+	if s.fired || time.Since(s.since) < r.For {
+		return false, nil
+	}
+
+	s.fired = true
+	return true, nil
+}
@@ -0,0 +1,131 @@
+package rules
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"shelly-cloud-logger/shelly"
+)
+
+// TestRuleDebouncePerDevice guards against the regression where a single
+// Rule's debounce state was shared across every device: a non-violating
+// device would reset a violating device's "violated since" timer on every
+// poll, so a continuously-violating device could never fire its alert.
+func TestRuleDebouncePerDevice(t *testing.T) {
+	rule, err := NewRule("power > 2000", "power > 2000", "log", 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewRule: %v", err)
+	}
+
+	violating := &shelly.DeviceStatus{Power: 3000}
+	notViolating := &shelly.DeviceStatus{Power: 100}
+
+	if fire, err := rule.Evaluate("device-a", violating); err != nil || fire {
+		t.Fatalf("device-a first evaluation: fire=%v err=%v, want fire=false (still inside the for: window)", fire, err)
+	}
+	// device-b never violates; it must not reset device-a's "since" timer.
+	for i := 0; i < 3; i++ {
+		if fire, err := rule.Evaluate("device-b", notViolating); err != nil || fire {
+			t.Fatalf("device-b evaluation: fire=%v err=%v, want fire=false", fire, err)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if fire, err := rule.Evaluate("device-a", violating); err != nil || !fire {
+		t.Fatalf("device-a evaluation after the for: window: fire=%v err=%v, want fire=true (device-b must not have reset the timer)", fire, err)
+	}
+}
+
+// TestRuleFiresOnceThenRearms guards against the regression where Evaluate
+// returned true on every single poll once the for: window had elapsed, so
+// a sustained violation fired its sink indefinitely. A violation should
+// fire once per episode, and only fire again after the condition clears
+// and is re-triggered.
+func TestRuleFiresOnceThenRearms(t *testing.T) {
+	rule, err := NewRule("power > 2000", "power > 2000", "log", 0)
+	if err != nil {
+		t.Fatalf("NewRule: %v", err)
+	}
+
+	violating := &shelly.DeviceStatus{Power: 3000}
+	notViolating := &shelly.DeviceStatus{Power: 100}
+
+	if fire, err := rule.Evaluate("device-a", violating); err != nil || !fire {
+		t.Fatalf("first violating poll: fire=%v err=%v, want fire=true", fire, err)
+	}
+	for i := 0; i < 5; i++ {
+		if fire, err := rule.Evaluate("device-a", violating); err != nil || fire {
+			t.Fatalf("sustained violation poll %d: fire=%v err=%v, want fire=false (already fired this episode)", i, fire, err)
+		}
+	}
+
+	if fire, err := rule.Evaluate("device-a", notViolating); err != nil || fire {
+		t.Fatalf("recovery poll: fire=%v err=%v, want fire=false", fire, err)
+	}
+
+	if fire, err := rule.Evaluate("device-a", violating); err != nil || !fire {
+		t.Fatalf("re-triggered poll: fire=%v err=%v, want fire=true (new episode)", fire, err)
+	}
+}
+
+// TestRuleEvaluateConcurrent exercises the same Rule from many goroutines at
+// once, matching how Engine.Evaluate is invoked from the concurrent
+// scheduler; run with -race to catch a data race on the debounce state.
+func TestRuleEvaluateConcurrent(t *testing.T) {
+	rule, err := NewRule("power > 2000", "power > 2000", "log", 0)
+	if err != nil {
+		t.Fatalf("NewRule: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for _, device := range []string{"device-a", "device-b", "device-c"} {
+			wg.Add(1)
+			go func(device string) {
+				defer wg.Done()
+				status := &shelly.DeviceStatus{Power: 3000}
+				if _, err := rule.Evaluate(device, status); err != nil {
+					t.Errorf("Evaluate(%s): %v", device, err)
+				}
+			}(device)
+		}
+	}
+	wg.Wait()
+}
+
+// TestEngineEvaluateMultiDevice is the Engine-level version of the same
+// regression: a rule shared across two devices via the Engine must track
+// each device's debounce independently.
+func TestEngineEvaluateMultiDevice(t *testing.T) {
+	var fired []string
+	engine := NewEngine(map[string]Sink{
+		"log": fireRecorder{fired: &fired},
+	})
+	if err := engine.AddRule("power > 2000", time.Millisecond, "log"); err != nil {
+		t.Fatalf("AddRule: %v", err)
+	}
+
+	violating := &shelly.DeviceStatus{Power: 3000}
+	notViolating := &shelly.DeviceStatus{Power: 100}
+
+	engine.Evaluate("device-a", violating)
+	engine.Evaluate("device-b", notViolating)
+	time.Sleep(2 * time.Millisecond)
+	engine.Evaluate("device-a", violating)
+	engine.Evaluate("device-b", notViolating)
+
+	if len(fired) != 1 || fired[0] != "device-a" {
+		t.Fatalf("fired = %v, want exactly [device-a]", fired)
+	}
+}
+
+type fireRecorder struct {
+	fired *[]string
+}
+
+func (f fireRecorder) Fire(ruleName, deviceName string, status *shelly.DeviceStatus) error {
+	*f.fired = append(*f.fired, deviceName)
+	return nil
+}
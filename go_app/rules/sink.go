@@ -0,0 +1,91 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"shelly-cloud-logger/shelly"
+)
+
+// Sink delivers a fired rule to wherever the user configured it to go.
+type Sink interface {
+	Fire(ruleName, deviceName string, status *shelly.DeviceStatus) error
+}
+
+// LogSink just logs the alert; it's always registered under "log".
+type LogSink struct{}
+
+func (LogSink) Fire(ruleName, deviceName string, status *shelly.DeviceStatus) error {
+	log.Printf("ALERT: rule %q fired for %s (online=%v power=%.1fW)", ruleName, deviceName, status.Online, status.Power)
+	return nil
+}
+
+// WebhookSink POSTs a JSON payload describing the fired rule to a URL.
+type WebhookSink struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewWebhookSink creates a sink that posts to url.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *WebhookSink) Fire(ruleName, deviceName string, status *shelly.DeviceStatus) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"rule":   ruleName,
+		"device": deviceName,
+		"status": status,
+		"time":   time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	resp, err := w.HTTPClient.Post(w.URL, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned HTTP %d", w.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// PublishFunc publishes a payload to an MQTT topic; satisfied by
+// mqtt.Subscriber.Publish without the rules package depending on an MQTT
+// client library directly.
+type PublishFunc func(topic string, payload []byte) error
+
+// MQTTSink publishes a JSON payload describing the fired rule to an MQTT
+// topic via publish.
+type MQTTSink struct {
+	publish PublishFunc
+	topic   string
+}
+
+// NewMQTTSink creates a sink that publishes to topic via publish.
+func NewMQTTSink(publish PublishFunc, topic string) *MQTTSink {
+	return &MQTTSink{publish: publish, topic: topic}
+}
+
+func (m *MQTTSink) Fire(ruleName, deviceName string, status *shelly.DeviceStatus) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"rule":   ruleName,
+		"device": deviceName,
+		"status": status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal mqtt sink payload: %w", err)
+	}
+	return m.publish(m.topic, payload)
+}
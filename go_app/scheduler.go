@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"shelly-cloud-logger/httpapi"
+	"shelly-cloud-logger/rules"
+	"shelly-cloud-logger/shelly"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api"
+)
+
+// cloudStatusFetcher is satisfied by *shelly.Client. It's defined here,
+// rather than having the scheduler depend on the concrete type directly, so
+// tests can exercise PollAll's scheduling behavior with a fake.
+type cloudStatusFetcher interface {
+	GetDeviceStatusV2(ctx context.Context, deviceID string) (map[string]interface{}, error)
+}
+
+// localStatusFetcher is satisfied by *shelly.LocalClient, for the same
+// reason as cloudStatusFetcher.
+type localStatusFetcher interface {
+	GetDeviceStatus(host string, generation int, username, password string) (map[string]interface{}, error)
+}
+
+// RateLimiter is a token-bucket limiter shared across polling goroutines so
+// overall request volume stays under the Shelly Cloud API's rate limit
+// regardless of how many devices are polled concurrently.
+type RateLimiter struct {
+	tokens   chan struct{}
+	interval time.Duration
+	stop     chan struct{}
+}
+
+// NewRateLimiter creates a limiter allowing ratePerSec requests per second,
+// with burst as the number of requests that may fire back-to-back before
+// the limiter starts pacing them.
+func NewRateLimiter(ratePerSec float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	if ratePerSec <= 0 {
+		ratePerSec = 1
+	}
+
+	rl := &RateLimiter{
+		tokens:   make(chan struct{}, burst),
+		interval: time.Duration(float64(time.Second) / ratePerSec),
+		stop:     make(chan struct{}),
+	}
+	for i := 0; i < burst; i++ {
+		rl.tokens <- struct{}{}
+	}
+	go rl.refill()
+	return rl
+}
+
+func (rl *RateLimiter) refill() {
+	ticker := time.NewTicker(rl.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case rl.tokens <- struct{}{}:
+			default:
+				// Bucket already full.
+			}
+		case <-rl.stop:
+			return
+		}
+	}
+}
+
+// Wait blocks until a token is available or ctx is cancelled.
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	select {
+	case <-rl.tokens:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the limiter's refill goroutine.
+func (rl *RateLimiter) Close() {
+	close(rl.stop)
+}
+
+// deviceBackoff tracks per-device exponential backoff so a device that's
+// throttled or down doesn't get retried on every poll cycle, and doesn't
+// block devices that are healthy.
+type deviceBackoff struct {
+	mu      sync.Mutex
+	attempt int
+	until   time.Time
+}
+
+// remaining reports how long this device should still be skipped for, or
+// zero if it's clear to poll.
+func (b *deviceBackoff) remaining() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if d := time.Until(b.until); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// recordFailure schedules the next backoff window. If retryAfter is set
+// (from an HTTP Retry-After header) it's honored as-is; otherwise the wait
+// grows exponentially, capped at 64s, with up to 50% jitter to avoid
+// devices synchronizing their retries.
+func (b *deviceBackoff) recordFailure(retryAfter time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.attempt++
+	wait := retryAfter
+	if wait <= 0 {
+		shift := b.attempt
+		if shift > 6 {
+			shift = 6
+		}
+		base := time.Duration(1<<uint(shift)) * time.Second
+		wait = base + time.Duration(rand.Int63n(int64(base)/2+1))
+	}
+	b.until = time.Now().Add(wait)
+}
+
+// recordSuccess clears the backoff state after a successful poll.
+func (b *deviceBackoff) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempt = 0
+	b.until = time.Time{}
+}
+
+// Scheduler polls devices concurrently, up to a configured parallelism,
+// through a shared rate limiter and with per-device backoff on failures.
+type Scheduler struct {
+	cloudClient cloudStatusFetcher
+	localClient localStatusFetcher
+	writeAPI    api.WriteAPI
+	limiter     *RateLimiter
+	parallelism int
+	rulesEngine *rules.Engine
+	registry    *httpapi.Registry
+	metrics     *httpapi.Metrics
+
+	backoffsMu sync.Mutex
+	backoffs   map[string]*deviceBackoff
+}
+
+// NewScheduler builds a Scheduler around the given clients, rate limiter,
+// and worker parallelism. rulesEngine, registry, and metrics may all be nil
+// if the corresponding feature isn't configured.
+func NewScheduler(cloudClient cloudStatusFetcher, localClient localStatusFetcher, writeAPI api.WriteAPI, limiter *RateLimiter, parallelism int, rulesEngine *rules.Engine, registry *httpapi.Registry, metrics *httpapi.Metrics) *Scheduler {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	return &Scheduler{
+		cloudClient: cloudClient,
+		localClient: localClient,
+		writeAPI:    writeAPI,
+		limiter:     limiter,
+		parallelism: parallelism,
+		rulesEngine: rulesEngine,
+		registry:    registry,
+		metrics:     metrics,
+		backoffs:    make(map[string]*deviceBackoff),
+	}
+}
+
+func (s *Scheduler) backoffFor(deviceID string) *deviceBackoff {
+	s.backoffsMu.Lock()
+	defer s.backoffsMu.Unlock()
+	b, ok := s.backoffs[deviceID]
+	if !ok {
+		b = &deviceBackoff{}
+		s.backoffs[deviceID] = b
+	}
+	return b
+}
+
+// PollAll polls every device concurrently (bounded by parallelism),
+// skipping any device still inside its backoff window rather than blocking
+// the rest of the poll cycle on it.
+func (s *Scheduler) PollAll(ctx context.Context, devices []DeviceConfig) {
+	sem := make(chan struct{}, s.parallelism)
+	var wg sync.WaitGroup
+
+	for _, device := range devices {
+		device := device
+		b := s.backoffFor(device.ID)
+		if remaining := b.remaining(); remaining > 0 {
+			log.Printf("Skipping %s (%s), backing off for %s", device.Name, device.ID, remaining.Round(time.Second))
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// Only cloud-transport devices share the Shelly Cloud rate limit;
+			// local devices are polled directly over the LAN and shouldn't
+			// queue behind it.
+			if !device.IsLocal() {
+				if err := s.limiter.Wait(ctx); err != nil {
+					return
+				}
+			}
+
+			err := processDevice(ctx, s.cloudClient, s.localClient, s.writeAPI, s.rulesEngine, s.registry, s.metrics, device)
+			var apiErr *shelly.APIError
+			switch {
+			case err == nil:
+				b.recordSuccess()
+			case errors.As(err, &apiErr) && (apiErr.StatusCode == 429 || apiErr.StatusCode >= 500):
+				b.recordFailure(apiErr.RetryAfter)
+			default:
+				b.recordFailure(0)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+)
+
+func TestDeviceBackoffExponentialGrowthAndCap(t *testing.T) {
+	b := &deviceBackoff{}
+
+	// The base wait (before jitter) should at least double each failure
+	// up to the shift=6 cap (64s base), after which jitter alone can make
+	// consecutive waits non-monotonic, so only the bound is checked there.
+	for i := 0; i < 10; i++ {
+		b.recordFailure(0)
+		wait := b.remaining()
+		if wait <= 0 {
+			t.Fatalf("attempt %d: remaining() = %v, want > 0 right after a failure", i, wait)
+		}
+
+		shift := i + 1
+		if shift > 6 {
+			shift = 6
+		}
+		base := time.Duration(1<<uint(shift)) * time.Second
+		if wait < base {
+			t.Fatalf("attempt %d: remaining() = %v, want >= base %v", i, wait, base)
+		}
+		if wait > base+base/2 { // base plus up to 50% jitter
+			t.Fatalf("attempt %d: remaining() = %v, exceeds base %v plus 50%% jitter", i, wait, base)
+		}
+	}
+}
+
+func TestDeviceBackoffRetryAfterOverride(t *testing.T) {
+	b := &deviceBackoff{}
+	b.recordFailure(5 * time.Second)
+
+	wait := b.remaining()
+	if wait <= 4*time.Second || wait > 5*time.Second {
+		t.Fatalf("remaining() = %v, want ~5s honoring Retry-After exactly", wait)
+	}
+}
+
+func TestDeviceBackoffRecordSuccessClears(t *testing.T) {
+	b := &deviceBackoff{}
+	b.recordFailure(time.Minute)
+	if b.remaining() <= 0 {
+		t.Fatal("expected a nonzero backoff window after a failure")
+	}
+
+	b.recordSuccess()
+	if wait := b.remaining(); wait != 0 {
+		t.Fatalf("remaining() after recordSuccess() = %v, want 0", wait)
+	}
+}
+
+// fakeCloudClient counts calls and never actually hits the network.
+type fakeCloudClient struct {
+	calls int32
+}
+
+func (f *fakeCloudClient) GetDeviceStatusV2(ctx context.Context, deviceID string) (map[string]interface{}, error) {
+	atomic.AddInt32(&f.calls, 1)
+	return map[string]interface{}{"online": true, "status": map[string]interface{}{}}, nil
+}
+
+// fakeLocalClient counts calls and never actually hits the network.
+type fakeLocalClient struct {
+	calls int32
+}
+
+func (f *fakeLocalClient) GetDeviceStatus(host string, generation int, username, password string) (map[string]interface{}, error) {
+	atomic.AddInt32(&f.calls, 1)
+	return map[string]interface{}{"online": true, "status": map[string]interface{}{}}, nil
+}
+
+// TestPollAllLocalBypassesLimiter starves the shared rate limiter down to a
+// single token, then polls two cloud devices and several local devices
+// together. Local devices must not queue behind the cloud-oriented limiter:
+// they should all complete even though the limiter has nothing left to
+// give out. Run with -race to confirm there's no data race between the
+// concurrent workers.
+func TestPollAllLocalBypassesLimiter(t *testing.T) {
+	limiter := NewRateLimiter(0.0001, 1) // effectively one token, ever, for this test's duration
+	defer limiter.Close()
+
+	cloud := &fakeCloudClient{}
+	local := &fakeLocalClient{}
+
+	// processDevice calls WritePoint on every poll (an offline point on a
+	// fetch error, a full point after a successful parse), and WritePoint on
+	// a nil api.WriteAPI would panic, so route through a no-op fake.
+	scheduler := NewScheduler(cloud, local, noopWriteAPI{}, limiter, 8, nil, nil, nil)
+
+	devices := []DeviceConfig{
+		{Name: "cloud-1", ID: "cloud-1", Transport: "cloud"},
+		{Name: "cloud-2", ID: "cloud-2", Transport: "cloud"},
+		{Name: "local-1", ID: "local-1", Transport: "local", Host: "127.0.0.1"},
+		{Name: "local-2", ID: "local-2", Transport: "local", Host: "127.0.0.1"},
+		{Name: "local-3", ID: "local-3", Transport: "local", Host: "127.0.0.1"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	scheduler.PollAll(ctx, devices)
+
+	if got := atomic.LoadInt32(&local.calls); got != 3 {
+		t.Errorf("local calls = %d, want 3 (local devices must bypass the rate limiter)", got)
+	}
+	if got := atomic.LoadInt32(&cloud.calls); got > 1 {
+		t.Errorf("cloud calls = %d, want at most 1 (only the single available token should let a cloud device through)", got)
+	}
+}
+
+// noopWriteAPI discards every point instead of batching/sending it, so
+// scheduler tests don't need a live InfluxDB to exercise processDevice.
+type noopWriteAPI struct{}
+
+func (noopWriteAPI) WriteRecord(line string)                       {}
+func (noopWriteAPI) WritePoint(point *write.Point)                 {}
+func (noopWriteAPI) Flush()                                        {}
+func (noopWriteAPI) Errors() <-chan error                          { return nil }
+func (noopWriteAPI) SetWriteFailedCallback(cb api.WriteFailedCallback) {}
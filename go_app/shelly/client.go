@@ -2,13 +2,45 @@ package shelly
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
-// DeviceStatus represents the standardized status of a device
+// APIError is returned by GetDeviceStatusV2 when Shelly Cloud responds with
+// any status other than 200, carrying enough information for a caller to decide
+// whether and how long to back off before retrying.
+type APIError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("shelly cloud HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// parseRetryAfter interprets the Retry-After header as either a number of
+// seconds or, failing that, ignores it (Shelly Cloud does not send the
+// HTTP-date form in practice).
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	return 0
+}
+
+// DeviceStatus represents the standardized status of a device. Most fields
+// are populated by whichever Profile matches the raw payload; fields that
+// don't apply to a given device type (e.g. Humidity on a switch) are left
+// nil/zero.
 type DeviceStatus struct {
 	Online      bool
 	Output      bool
@@ -17,6 +49,10 @@ type DeviceStatus struct {
 	Voltage     *float64
 	Current     *float64
 	Temperature *float64
+	Humidity    *float64
+	Battery     *float64
+	PhasePower  *[3]float64
+	PowerFactor *float64
 }
 
 // Client handles Shelly Cloud API requests
@@ -37,8 +73,11 @@ func NewClient(serverURI, authKey string) *Client {
 	}
 }
 
-// GetDeviceStatusV2 fetches the raw status from Shelly Cloud API
-func (c *Client) GetDeviceStatusV2(deviceID string) (map[string]interface{}, error) {
+// GetDeviceStatusV2 fetches the raw status from Shelly Cloud API. It honors
+// ctx for cancellation, and on a non-200 response returns a *APIError
+// carrying the status code and any Retry-After hint so callers can back off
+// per device instead of blocking the whole poll cycle.
+func (c *Client) GetDeviceStatusV2(ctx context.Context, deviceID string) (map[string]interface{}, error) {
 	url := fmt.Sprintf("https://%s/v2/devices/api/get", c.ServerURI)
 
 	payload := map[string]interface{}{
@@ -50,7 +89,7 @@ func (c *Client) GetDeviceStatusV2(deviceID string) (map[string]interface{}, err
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonPayload))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -67,7 +106,12 @@ func (c *Client) GetDeviceStatusV2(deviceID string) (map[string]interface{}, err
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Body:       string(body),
+		}
 	}
 
 	var rawData interface{}
@@ -102,7 +146,11 @@ func (c *Client) GetDeviceStatusV2(deviceID string) (map[string]interface{}, err
 	return nil, fmt.Errorf("unexpected response format")
 }
 
-// ParseDeviceStatus parses the raw API response into a standardized structure
+// ParseDeviceStatus parses the raw API response into a standardized
+// structure. The "status" object is handed to the registered Profiles in
+// order; the first one that matches handles the parse. Supporting a new
+// device type is a matter of registering a new Profile, not editing this
+// function.
 func ParseDeviceStatus(deviceStatus map[string]interface{}, channel int) (*DeviceStatus, error) {
 	if deviceStatus == nil {
 		return nil, fmt.Errorf("device status is nil")
@@ -115,146 +163,34 @@ func ParseDeviceStatus(deviceStatus map[string]interface{}, channel int) (*Devic
 		Energy: 0.0,
 	}
 
-    // Online status
-    if online, ok := deviceStatus["online"]; ok {
-        // Could be float or bool in JSON unmarshal
-        switch v := online.(type) {
-        case bool:
-            result.Online = v
-        case float64:
-            result.Online = v == 1
-        }
-    }
-
-    statusObj, ok := deviceStatus["status"].(map[string]interface{})
-    if !ok {
-        // If no status object, return what we have (likely offline)
-        return result, nil
-    }
-
-    channelKey := fmt.Sprintf("switch:%d", channel)
-
-    // Helper function to safely get float
-    getFloat := func(m map[string]interface{}, key string) (float64, bool) {
-        if v, ok := m[key]; ok {
-            if f, ok := v.(float64); ok {
-                return f, true
-            }
-        }
-        return 0, false
-    }
-
-    // Try switch:x
-    if switchDataRaw, ok := statusObj[channelKey]; ok {
-        if switchData, ok := switchDataRaw.(map[string]interface{}); ok {
-            if out, ok := switchData["output"]; ok {
-                 if b, ok := out.(bool); ok {
-                     result.Output = b
-                 }
-            }
-
-            if p, ok := getFloat(switchData, "apower"); ok {
-                result.Power = p
-            }
-
-            if v, ok := getFloat(switchData, "voltage"); ok {
-                val := v
-                result.Voltage = &val
-            }
-            if c, ok := getFloat(switchData, "current"); ok {
-                val := c
-                result.Current = &val
-            }
-
-            if aenergyRaw, ok := switchData["aenergy"]; ok {
-                if aenergy, ok := aenergyRaw.(map[string]interface{}); ok {
-                    if t, ok := getFloat(aenergy, "total"); ok {
-                        result.Energy = t
-                    }
-                }
-            }
-
-            if tempRaw, ok := switchData["temperature"]; ok {
-                if temp, ok := tempRaw.(map[string]interface{}); ok {
-                    if t, ok := getFloat(temp, "tC"); ok {
-                        val := t
-                        result.Temperature = &val
-                    }
-                }
-            }
-            return result, nil
-        }
-    }
-
-    // Try Gen 1 relays/meters
-    if relaysRaw, ok := statusObj["relays"]; ok {
-        if relays, ok := relaysRaw.([]interface{}); ok {
-            if channel < len(relays) {
-                if relay, ok := relays[channel].(map[string]interface{}); ok {
-                    if ison, ok := relay["ison"]; ok {
-                         if b, ok := ison.(bool); ok {
-                             result.Output = b
-                         }
-                    }
-                }
-            }
-        }
-    }
-
-    if metersRaw, ok := statusObj["meters"]; ok {
-        if meters, ok := metersRaw.([]interface{}); ok {
-            if channel < len(meters) {
-                if meter, ok := meters[channel].(map[string]interface{}); ok {
-                    if p, ok := getFloat(meter, "power"); ok {
-                        result.Power = p
-                    }
-                    if t, ok := getFloat(meter, "total"); ok {
-                        result.Energy = t
-                    }
-                }
-            }
-        }
-    }
-
-    // Gen 1 temperature
-    if tmpRaw, ok := statusObj["tmp"]; ok {
-        if tmp, ok := tmpRaw.(map[string]interface{}); ok {
-             if t, ok := getFloat(tmp, "tC"); ok {
-                 val := t
-                 result.Temperature = &val
-             }
-        }
-    }
-
-    // If we found relays/meters, we are done
-    if _, ok := statusObj["relays"]; ok {
-        return result, nil
-    }
+	// Online status
+	if online, ok := deviceStatus["online"]; ok {
+		// Could be float or bool in JSON unmarshal
+		switch v := online.(type) {
+		case bool:
+			result.Online = v
+		case float64:
+			result.Online = v == 1
+		}
+	}
 
-    // Try cover:0
-    if coverRaw, ok := statusObj["cover:0"]; ok {
-        if cover, ok := coverRaw.(map[string]interface{}); ok {
-            if state, ok := cover["state"].(string); ok {
-                result.Output = state == "open"
-            }
-            if p, ok := getFloat(cover, "apower"); ok {
-                result.Power = p
-            }
-            return result, nil
-        }
-    }
+	statusObj, ok := deviceStatus["status"].(map[string]interface{})
+	if !ok {
+		// If no status object, return what we have (likely offline)
+		return result, nil
+	}
 
-    // Try light:0
-    if lightRaw, ok := statusObj["light:0"]; ok {
-        if light, ok := lightRaw.(map[string]interface{}); ok {
-            if out, ok := light["output"]; ok {
-                if b, ok := out.(bool); ok {
-                    result.Output = b
-                }
-            }
-            return result, nil
-        }
-    }
+	for _, p := range profiles {
+		if !p.Match(statusObj) {
+			continue
+		}
+		parsed, err := p.Parse(statusObj, channel)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Online = result.Online
+		return parsed, nil
+	}
 
 	return result, nil
 }
@@ -0,0 +1,294 @@
+package shelly
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LocalClient talks directly to a Gen1/Gen2/Gen3 device on the LAN instead of
+// routing requests through Shelly Cloud. It is used when a DeviceConfig has
+// transport: local, and avoids Shelly Cloud's rate limits entirely.
+type LocalClient struct {
+	HTTPClient *http.Client
+}
+
+// NewLocalClient creates a new client for talking to devices on the LAN.
+func NewLocalClient() *LocalClient {
+	return &LocalClient{
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// GetDeviceStatus fetches and normalizes the status of a local device,
+// dispatching to the Gen1 or Gen2/Gen3 code path based on generation.
+// The returned map has the same {"online": bool, "status": {...}} shape
+// that GetDeviceStatusV2 returns, so it can be fed straight into
+// ParseDeviceStatus.
+func (c *LocalClient) GetDeviceStatus(host string, generation int, username, password string) (map[string]interface{}, error) {
+	switch generation {
+	case 1:
+		return c.getGen1Status(host, username, password)
+	case 2, 3:
+		return c.getGen2Status(host, username, password)
+	default:
+		return nil, fmt.Errorf("unsupported device generation: %d", generation)
+	}
+}
+
+// getGen1Status fetches the flat status JSON from a Gen1 device's
+// GET /status endpoint (relays, meters, tmp, wifi_sta, ...), retrying with
+// HTTP digest auth if the device responds 401.
+func (c *LocalClient) getGen1Status(host, username, password string) (map[string]interface{}, error) {
+	url := fmt.Sprintf("http://%s/status", host)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		req, err = http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Authorization", digestAuthHeader(challenge, "GET", "/status", username, password))
+
+		resp, err = c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("authenticated request to %s failed: %w", host, err)
+		}
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, host)
+	}
+
+	var flat map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&flat); err != nil {
+		return nil, fmt.Errorf("failed to decode gen1 status: %w", err)
+	}
+
+	return map[string]interface{}{
+		"online": true,
+		"status": flat,
+	}, nil
+}
+
+// rpcRequest is a JSON-RPC 2.0 request as used by Shelly.GetStatus and, once
+// challenged, carries an "auth" frame per Gen2's digest-over-RPC scheme.
+type rpcRequest struct {
+	ID     int       `json:"id"`
+	Method string    `json:"method"`
+	Auth   *gen2Auth `json:"auth,omitempty"`
+}
+
+type rpcResponse struct {
+	ID     int                    `json:"id"`
+	Result map[string]interface{} `json:"result,omitempty"`
+	Error  *rpcError              `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// gen2AuthChallenge is the payload embedded in a Gen2 401 error message.
+type gen2AuthChallenge struct {
+	AuthType  string `json:"auth_type"`
+	Nonce     int64  `json:"nonce"`
+	Nc        int    `json:"nc"`
+	Realm     string `json:"realm"`
+	Algorithm string `json:"algorithm"`
+}
+
+// gen2Auth is the "auth" frame sent back to satisfy a Gen2 401 challenge.
+type gen2Auth struct {
+	Realm     string `json:"realm"`
+	Username  string `json:"username"`
+	Nonce     int64  `json:"nonce"`
+	Cnonce    int64  `json:"cnonce"`
+	Response  string `json:"response"`
+	Algorithm string `json:"algorithm"`
+}
+
+// getGen2Status calls the Shelly.GetStatus RPC method over POST /rpc,
+// satisfying the SHA-256 nonce/cnonce auth frame if the device challenges
+// the first request.
+func (c *LocalClient) getGen2Status(host, username, password string) (map[string]interface{}, error) {
+	url := fmt.Sprintf("http://%s/rpc", host)
+
+	result, rpcErr, err := c.callRPC(url, rpcRequest{ID: 1, Method: "Shelly.GetStatus"})
+	if err != nil {
+		return nil, err
+	}
+
+	if rpcErr != nil {
+		if rpcErr.Code != 401 {
+			return nil, fmt.Errorf("rpc error %d: %s", rpcErr.Code, rpcErr.Message)
+		}
+
+		var challenge gen2AuthChallenge
+		if err := json.Unmarshal([]byte(rpcErr.Message), &challenge); err != nil {
+			return nil, fmt.Errorf("failed to parse gen2 auth challenge: %w", err)
+		}
+
+		auth := buildGen2Auth(challenge, username, password)
+		result, rpcErr, err = c.callRPC(url, rpcRequest{ID: 2, Method: "Shelly.GetStatus", Auth: auth})
+		if err != nil {
+			return nil, err
+		}
+		if rpcErr != nil {
+			return nil, fmt.Errorf("rpc error %d after auth: %s", rpcErr.Code, rpcErr.Message)
+		}
+	}
+
+	return map[string]interface{}{
+		"online": true,
+		"status": result,
+	}, nil
+}
+
+func (c *LocalClient) callRPC(url string, reqBody rpcRequest) (map[string]interface{}, *rpcError, error) {
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal rpc request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("rpc request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read rpc response: %w", err)
+	}
+
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(body, &rpcResp); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode rpc response: %w", err)
+	}
+
+	return rpcResp.Result, rpcResp.Error, nil
+}
+
+// buildGen2Auth computes the SHA-256 response for a Gen2 auth challenge.
+// Per Shelly's RPC auth scheme: ha1 = SHA256("user:realm:password"),
+// ha2 = SHA256("dummy_method:dummy_uri"), response =
+// SHA256("ha1:nonce:nc:cnonce:auth:ha2").
+func buildGen2Auth(challenge gen2AuthChallenge, username, password string) *gen2Auth {
+	return buildGen2AuthWithCnonce(challenge, username, password, randomCnonce())
+}
+
+// buildGen2AuthWithCnonce is buildGen2Auth with the cnonce pulled out as a
+// parameter so the hash construction can be pinned against a fixed
+// challenge/response pair in tests.
+func buildGen2AuthWithCnonce(challenge gen2AuthChallenge, username, password string, cnonce int64) *gen2Auth {
+	ha1 := sha256Hex(fmt.Sprintf("%s:%s:%s", username, challenge.Realm, password))
+	ha2 := sha256Hex("dummy_method:dummy_uri")
+	response := sha256Hex(fmt.Sprintf("%s:%d:%d:%d:auth:%s", ha1, challenge.Nonce, challenge.Nc, cnonce, ha2))
+
+	return &gen2Auth{
+		Realm:     challenge.Realm,
+		Username:  username,
+		Nonce:     challenge.Nonce,
+		Cnonce:    cnonce,
+		Response:  response,
+		Algorithm: "SHA-256",
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("%x", sum)
+}
+
+func randomCnonce() int64 {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UnixNano()
+	}
+	var n int64
+	for _, v := range b {
+		n = n<<8 | int64(v)
+	}
+	if n < 0 {
+		n = -n
+	}
+	return n
+}
+
+var digestParamPattern = regexp.MustCompile(`(\w+)=("[^"]*"|[^,]+)`)
+
+// digestAuthHeader builds an RFC 2617 digest Authorization header for a
+// Gen1 device's WWW-Authenticate challenge (MD5, qop=auth).
+func digestAuthHeader(challenge, method, uri, username, password string) string {
+	return digestAuthHeaderWithCnonce(challenge, method, uri, username, password, fmt.Sprintf("%x", randomCnonce()))
+}
+
+// digestAuthHeaderWithCnonce is digestAuthHeader with the cnonce pulled out
+// as a parameter so the hash construction can be pinned against a known
+// RFC 2617 test vector.
+func digestAuthHeaderWithCnonce(challenge, method, uri, username, password, cnonce string) string {
+	params := map[string]string{}
+	for _, match := range digestParamPattern.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = strings.Trim(match[2], `"`)
+	}
+
+	realm := params["realm"]
+	nonce := params["nonce"]
+	qop := params["qop"]
+
+	ha1 := md5Hex(fmt.Sprintf("%s:%s:%s", username, realm, password))
+	ha2 := md5Hex(fmt.Sprintf("%s:%s", method, uri))
+
+	nc := "00000001"
+
+	var response string
+	if qop == "auth" {
+		response = md5Hex(fmt.Sprintf("%s:%s:%s:%s:%s:%s", ha1, nonce, nc, cnonce, qop, ha2))
+	} else {
+		response = md5Hex(fmt.Sprintf("%s:%s:%s", ha1, nonce, ha2))
+	}
+
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s"`,
+		username, realm, nonce, uri, response)
+	if qop == "auth" {
+		header += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s"`, qop, nc, cnonce)
+	}
+	return header
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return fmt.Sprintf("%x", sum)
+}
@@ -0,0 +1,68 @@
+package shelly
+
+// Profile recognizes and parses one category of device status payload
+// (a given generation + component, e.g. Gen2 switch or Gen1 roller). New
+// device types can be supported by registering a Profile without touching
+// ParseDeviceStatus itself.
+type Profile interface {
+	// Match reports whether raw (the device's "status" object) is handled
+	// by this profile.
+	Match(raw map[string]interface{}) bool
+	// Parse extracts a DeviceStatus from raw for the given channel. Only
+	// called when Match(raw) is true.
+	Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error)
+}
+
+// profiles is tried in registration order; more specific profiles should
+// register before more general fallbacks.
+var profiles []Profile
+
+// RegisterProfile adds a profile to the registry consulted by
+// ParseDeviceStatus. Third-party device types can be supported by calling
+// this from an init() function without modifying this package.
+func RegisterProfile(p Profile) {
+	profiles = append(profiles, p)
+}
+
+func init() {
+	RegisterProfile(gen2SwitchProfile{})
+	RegisterProfile(gen2CoverProfile{})
+	RegisterProfile(gen2DimmerProfile{})
+	RegisterProfile(gen2EMProfile{})
+	RegisterProfile(gen2PMProfile{})
+	RegisterProfile(hAndTProfile{})
+	RegisterProfile(gen1RelayMeterProfile{})
+	RegisterProfile(gen1RollerProfile{})
+	RegisterProfile(gen1BulbProfile{})
+}
+
+// getFloat safely reads a float64 field out of a raw JSON object.
+func getFloat(m map[string]interface{}, key string) (float64, bool) {
+	if v, ok := m[key]; ok {
+		if f, ok := v.(float64); ok {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// getBool safely reads a bool field out of a raw JSON object.
+func getBool(m map[string]interface{}, key string) (bool, bool) {
+	if v, ok := m[key]; ok {
+		if b, ok := v.(bool); ok {
+			return b, true
+		}
+	}
+	return false, false
+}
+
+// hasKeyWithPrefix reports whether raw has any key starting with prefix,
+// e.g. "switch:" matching "switch:0", "switch:1", ...
+func hasKeyWithPrefix(raw map[string]interface{}, prefix string) bool {
+	for k := range raw {
+		if len(k) >= len(prefix) && k[:len(prefix)] == prefix {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package shelly
+
+import "testing"
+
+func TestParseDeviceStatusGen2Switch(t *testing.T) {
+	raw := map[string]interface{}{
+		"online": true,
+		"status": map[string]interface{}{
+			"switch:0": map[string]interface{}{
+				"output": true,
+				"apower": 42.5,
+				"voltage": 231.0,
+				"current": 0.2,
+				"aenergy": map[string]interface{}{"total": 1234.0},
+			},
+		},
+	}
+
+	status, err := ParseDeviceStatus(raw, 0)
+	if err != nil {
+		t.Fatalf("ParseDeviceStatus: %v", err)
+	}
+	if !status.Online || !status.Output {
+		t.Errorf("Online/Output = %v/%v, want true/true", status.Online, status.Output)
+	}
+	if status.Power != 42.5 {
+		t.Errorf("Power = %v, want 42.5", status.Power)
+	}
+	if status.Voltage == nil || *status.Voltage != 231.0 {
+		t.Errorf("Voltage = %v, want 231.0", status.Voltage)
+	}
+	if status.Energy != 1234.0 {
+		t.Errorf("Energy = %v, want 1234.0", status.Energy)
+	}
+}
+
+func TestParseDeviceStatusGen1RelayMeter(t *testing.T) {
+	raw := map[string]interface{}{
+		"online": true,
+		"status": map[string]interface{}{
+			"relays": []interface{}{
+				map[string]interface{}{"ison": true},
+			},
+			"meters": []interface{}{
+				map[string]interface{}{"power": 10.0, "total": 500.0},
+			},
+			"tmp": map[string]interface{}{"tC": 35.5},
+		},
+	}
+
+	status, err := ParseDeviceStatus(raw, 0)
+	if err != nil {
+		t.Fatalf("ParseDeviceStatus: %v", err)
+	}
+	if !status.Output {
+		t.Errorf("Output = false, want true")
+	}
+	if status.Power != 10.0 || status.Energy != 500.0 {
+		t.Errorf("Power/Energy = %v/%v, want 10.0/500.0", status.Power, status.Energy)
+	}
+	if status.Temperature == nil || *status.Temperature != 35.5 {
+		t.Errorf("Temperature = %v, want 35.5", status.Temperature)
+	}
+}
+
+func TestParseDeviceStatusGen2EM(t *testing.T) {
+	raw := map[string]interface{}{
+		"online": true,
+		"status": map[string]interface{}{
+			"em:0": map[string]interface{}{
+				"total_act_power": 900.0,
+				"a_act_power":     300.0,
+				"b_act_power":     300.0,
+				"c_act_power":     300.0,
+				"a_voltage":       230.0,
+				"a_current":       1.3,
+				"a_pf":            0.95,
+			},
+			"emdata:0": map[string]interface{}{
+				"total_act": 5000.0,
+			},
+		},
+	}
+
+	status, err := ParseDeviceStatus(raw, 0)
+	if err != nil {
+		t.Fatalf("ParseDeviceStatus: %v", err)
+	}
+	if status.Power != 900.0 {
+		t.Errorf("Power = %v, want 900.0", status.Power)
+	}
+	if status.PhasePower == nil || *status.PhasePower != [3]float64{300, 300, 300} {
+		t.Errorf("PhasePower = %v, want [300 300 300]", status.PhasePower)
+	}
+	if status.PowerFactor == nil || *status.PowerFactor != 0.95 {
+		t.Errorf("PowerFactor = %v, want 0.95", status.PowerFactor)
+	}
+	if status.Energy != 5000.0 {
+		t.Errorf("Energy = %v, want 5000.0", status.Energy)
+	}
+}
+
+// TestParseDeviceStatusGen2EMZeroPhasePower guards against treating a
+// legitimate all-zero three-phase reading (e.g. an idle device overnight) as
+// "no phase data": PhasePower must still be non-nil when every phase read 0W.
+func TestParseDeviceStatusGen2EMZeroPhasePower(t *testing.T) {
+	raw := map[string]interface{}{
+		"online": true,
+		"status": map[string]interface{}{
+			"em:0": map[string]interface{}{
+				"total_act_power": 0.0,
+				"a_act_power":     0.0,
+				"b_act_power":     0.0,
+				"c_act_power":     0.0,
+			},
+		},
+	}
+
+	status, err := ParseDeviceStatus(raw, 0)
+	if err != nil {
+		t.Fatalf("ParseDeviceStatus: %v", err)
+	}
+	if status.PhasePower == nil {
+		t.Fatal("PhasePower = nil, want a non-nil all-zero array")
+	}
+	if *status.PhasePower != [3]float64{0, 0, 0} {
+		t.Errorf("PhasePower = %v, want [0 0 0]", status.PhasePower)
+	}
+}
+
+func TestParseDeviceStatusHAndT(t *testing.T) {
+	raw := map[string]interface{}{
+		"online": true,
+		"status": map[string]interface{}{
+			"humidity:0": map[string]interface{}{"rh": 55.0},
+			"devicepower:0": map[string]interface{}{
+				"battery": map[string]interface{}{"percent": 80.0},
+			},
+		},
+	}
+
+	status, err := ParseDeviceStatus(raw, 0)
+	if err != nil {
+		t.Fatalf("ParseDeviceStatus: %v", err)
+	}
+	if status.Humidity == nil || *status.Humidity != 55.0 {
+		t.Errorf("Humidity = %v, want 55.0", status.Humidity)
+	}
+	if status.Battery == nil || *status.Battery != 80.0 {
+		t.Errorf("Battery = %v, want 80.0", status.Battery)
+	}
+}
+
+func TestParseDeviceStatusUnmatchedFallsBackOffline(t *testing.T) {
+	raw := map[string]interface{}{
+		"online": false,
+		"status": map[string]interface{}{
+			"sys": map[string]interface{}{"mac": "aabbcc"},
+		},
+	}
+
+	status, err := ParseDeviceStatus(raw, 0)
+	if err != nil {
+		t.Fatalf("ParseDeviceStatus: %v", err)
+	}
+	if status.Online || status.Output || status.Power != 0 {
+		t.Errorf("unmatched status should stay zero-valued, got %+v", status)
+	}
+}
@@ -0,0 +1,330 @@
+package shelly
+
+import "fmt"
+
+// --- Gen2/Gen3 switch ---------------------------------------------------
+
+type gen2SwitchProfile struct{}
+
+func (gen2SwitchProfile) Match(raw map[string]interface{}) bool {
+	return hasKeyWithPrefix(raw, "switch:")
+}
+
+func (gen2SwitchProfile) Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error) {
+	switchData, ok := raw[fmt.Sprintf("switch:%d", channel)].(map[string]interface{})
+	if !ok {
+		return &DeviceStatus{}, nil
+	}
+
+	result := &DeviceStatus{}
+	if out, ok := getBool(switchData, "output"); ok {
+		result.Output = out
+	}
+	if p, ok := getFloat(switchData, "apower"); ok {
+		result.Power = p
+	}
+	if v, ok := getFloat(switchData, "voltage"); ok {
+		result.Voltage = &v
+	}
+	if c, ok := getFloat(switchData, "current"); ok {
+		result.Current = &c
+	}
+	if pf, ok := getFloat(switchData, "pf"); ok {
+		result.PowerFactor = &pf
+	}
+	if aenergy, ok := switchData["aenergy"].(map[string]interface{}); ok {
+		if t, ok := getFloat(aenergy, "total"); ok {
+			result.Energy = t
+		}
+	}
+	if temp, ok := switchData["temperature"].(map[string]interface{}); ok {
+		if t, ok := getFloat(temp, "tC"); ok {
+			result.Temperature = &t
+		}
+	}
+	return result, nil
+}
+
+// --- Gen2/Gen3 cover ------------------------------------------------------
+
+type gen2CoverProfile struct{}
+
+func (gen2CoverProfile) Match(raw map[string]interface{}) bool {
+	return hasKeyWithPrefix(raw, "cover:")
+}
+
+func (gen2CoverProfile) Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error) {
+	cover, ok := raw[fmt.Sprintf("cover:%d", channel)].(map[string]interface{})
+	if !ok {
+		return &DeviceStatus{}, nil
+	}
+
+	result := &DeviceStatus{}
+	if state, ok := cover["state"].(string); ok {
+		result.Output = state == "open"
+	}
+	if p, ok := getFloat(cover, "apower"); ok {
+		result.Power = p
+	}
+	if v, ok := getFloat(cover, "voltage"); ok {
+		result.Voltage = &v
+	}
+	if c, ok := getFloat(cover, "current"); ok {
+		result.Current = &c
+	}
+	if aenergy, ok := cover["aenergy"].(map[string]interface{}); ok {
+		if t, ok := getFloat(aenergy, "total"); ok {
+			result.Energy = t
+		}
+	}
+	return result, nil
+}
+
+// --- Plus/Pro dimmers (Gen2 light:N component) ----------------------------
+
+type gen2DimmerProfile struct{}
+
+func (gen2DimmerProfile) Match(raw map[string]interface{}) bool {
+	return hasKeyWithPrefix(raw, "light:")
+}
+
+func (gen2DimmerProfile) Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error) {
+	light, ok := raw[fmt.Sprintf("light:%d", channel)].(map[string]interface{})
+	if !ok {
+		return &DeviceStatus{}, nil
+	}
+
+	result := &DeviceStatus{}
+	if out, ok := getBool(light, "output"); ok {
+		result.Output = out
+	}
+	if p, ok := getFloat(light, "apower"); ok {
+		result.Power = p
+	}
+	if v, ok := getFloat(light, "voltage"); ok {
+		result.Voltage = &v
+	}
+	if c, ok := getFloat(light, "current"); ok {
+		result.Current = &c
+	}
+	if aenergy, ok := light["aenergy"].(map[string]interface{}); ok {
+		if t, ok := getFloat(aenergy, "total"); ok {
+			result.Energy = t
+		}
+	}
+	return result, nil
+}
+
+// --- Gen2 EM (three-phase energy meter) -----------------------------------
+
+type gen2EMProfile struct{}
+
+func (gen2EMProfile) Match(raw map[string]interface{}) bool {
+	return hasKeyWithPrefix(raw, "em:")
+}
+
+func (gen2EMProfile) Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error) {
+	em, ok := raw[fmt.Sprintf("em:%d", channel)].(map[string]interface{})
+	if !ok {
+		return &DeviceStatus{}, nil
+	}
+
+	result := &DeviceStatus{Output: true}
+
+	if p, ok := getFloat(em, "total_act_power"); ok {
+		result.Power = p
+	}
+	phases := [3]string{"a_act_power", "b_act_power", "c_act_power"}
+	var phasePower [3]float64
+	havePhasePower := false
+	for i, key := range phases {
+		if p, ok := getFloat(em, key); ok {
+			phasePower[i] = p
+			havePhasePower = true
+		}
+	}
+	if havePhasePower {
+		result.PhasePower = &phasePower
+	}
+	if v, ok := getFloat(em, "a_voltage"); ok {
+		result.Voltage = &v
+	}
+	if c, ok := getFloat(em, "a_current"); ok {
+		result.Current = &c
+	}
+	if pf, ok := getFloat(em, "a_pf"); ok {
+		result.PowerFactor = &pf
+	}
+
+	if emdata, ok := raw[fmt.Sprintf("emdata:%d", channel)].(map[string]interface{}); ok {
+		if t, ok := getFloat(emdata, "total_act"); ok {
+			result.Energy = t
+		}
+	}
+
+	return result, nil
+}
+
+// --- Gen2 PM mini (single-phase power meter, no switch) -------------------
+
+type gen2PMProfile struct{}
+
+func (gen2PMProfile) Match(raw map[string]interface{}) bool {
+	return hasKeyWithPrefix(raw, "pm1:")
+}
+
+func (gen2PMProfile) Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error) {
+	pm, ok := raw[fmt.Sprintf("pm1:%d", channel)].(map[string]interface{})
+	if !ok {
+		return &DeviceStatus{}, nil
+	}
+
+	result := &DeviceStatus{Output: true}
+	if p, ok := getFloat(pm, "apower"); ok {
+		result.Power = p
+	}
+	if v, ok := getFloat(pm, "voltage"); ok {
+		result.Voltage = &v
+	}
+	if c, ok := getFloat(pm, "current"); ok {
+		result.Current = &c
+	}
+	if aenergy, ok := pm["aenergy"].(map[string]interface{}); ok {
+		if t, ok := getFloat(aenergy, "total"); ok {
+			result.Energy = t
+		}
+	}
+	return result, nil
+}
+
+// --- H&T sensor (battery + humidity) --------------------------------------
+
+type hAndTProfile struct{}
+
+func (hAndTProfile) Match(raw map[string]interface{}) bool {
+	return hasKeyWithPrefix(raw, "humidity:") || hasKeyWithPrefix(raw, "devicepower:")
+}
+
+func (hAndTProfile) Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error) {
+	result := &DeviceStatus{}
+
+	if humidity, ok := raw[fmt.Sprintf("humidity:%d", channel)].(map[string]interface{}); ok {
+		if h, ok := getFloat(humidity, "rh"); ok {
+			result.Humidity = &h
+		}
+	}
+	if temp, ok := raw[fmt.Sprintf("temperature:%d", channel)].(map[string]interface{}); ok {
+		if t, ok := getFloat(temp, "tC"); ok {
+			result.Temperature = &t
+		}
+	}
+	if power, ok := raw[fmt.Sprintf("devicepower:%d", channel)].(map[string]interface{}); ok {
+		if battery, ok := power["battery"].(map[string]interface{}); ok {
+			if pct, ok := getFloat(battery, "percent"); ok {
+				result.Battery = &pct
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// --- Gen1 relay/meter ------------------------------------------------------
+
+type gen1RelayMeterProfile struct{}
+
+func (gen1RelayMeterProfile) Match(raw map[string]interface{}) bool {
+	_, ok := raw["relays"]
+	return ok
+}
+
+func (gen1RelayMeterProfile) Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error) {
+	result := &DeviceStatus{}
+
+	if relays, ok := raw["relays"].([]interface{}); ok && channel < len(relays) {
+		if relay, ok := relays[channel].(map[string]interface{}); ok {
+			if ison, ok := getBool(relay, "ison"); ok {
+				result.Output = ison
+			}
+		}
+	}
+
+	if meters, ok := raw["meters"].([]interface{}); ok && channel < len(meters) {
+		if meter, ok := meters[channel].(map[string]interface{}); ok {
+			if p, ok := getFloat(meter, "power"); ok {
+				result.Power = p
+			}
+			if t, ok := getFloat(meter, "total"); ok {
+				result.Energy = t
+			}
+		}
+	}
+
+	if tmp, ok := raw["tmp"].(map[string]interface{}); ok {
+		if t, ok := getFloat(tmp, "tC"); ok {
+			result.Temperature = &t
+		}
+	}
+
+	return result, nil
+}
+
+// --- Gen1 roller (Shelly 2.5 roller mode) ---------------------------------
+
+type gen1RollerProfile struct{}
+
+func (gen1RollerProfile) Match(raw map[string]interface{}) bool {
+	_, ok := raw["rollers"]
+	return ok
+}
+
+func (gen1RollerProfile) Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error) {
+	result := &DeviceStatus{}
+
+	if rollers, ok := raw["rollers"].([]interface{}); ok && channel < len(rollers) {
+		if roller, ok := rollers[channel].(map[string]interface{}); ok {
+			if state, ok := roller["state"].(string); ok {
+				result.Output = state == "open"
+			}
+			if p, ok := getFloat(roller, "power"); ok {
+				result.Power = p
+			}
+		}
+	}
+
+	if meters, ok := raw["meters"].([]interface{}); ok && channel < len(meters) {
+		if meter, ok := meters[channel].(map[string]interface{}); ok {
+			if t, ok := getFloat(meter, "total"); ok {
+				result.Energy = t
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// --- Gen1 bulb/RGBW (lights array) ----------------------------------------
+
+type gen1BulbProfile struct{}
+
+func (gen1BulbProfile) Match(raw map[string]interface{}) bool {
+	_, ok := raw["lights"]
+	return ok
+}
+
+func (gen1BulbProfile) Parse(raw map[string]interface{}, channel int) (*DeviceStatus, error) {
+	result := &DeviceStatus{}
+
+	if lights, ok := raw["lights"].([]interface{}); ok && channel < len(lights) {
+		if light, ok := lights[channel].(map[string]interface{}); ok {
+			if ison, ok := getBool(light, "ison"); ok {
+				result.Output = ison
+			}
+			if p, ok := getFloat(light, "power"); ok {
+				result.Power = p
+			}
+		}
+	}
+
+	return result, nil
+}